@@ -0,0 +1,287 @@
+// Copyright © 2023 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/karlseguin/ccache/v3"
+)
+
+// Cache is a named, typed key/value store. Instances are obtained from a CacheManager (or,
+// for ad-hoc use, NewUmanagedCache) rather than constructed directly.
+type Cache interface {
+	Get(key string) interface{}
+	GetString(key string) string
+	GetInt(key string) int
+	GetInt64(key string) int64
+	Set(key string, value interface{})
+	SetString(key string, value string)
+	SetInt(key string, value int)
+	SetInt64(key string, value int64)
+	Delete(key string) bool
+	IsEnabled() bool
+}
+
+// CacheBackend is the storage layer behind a Cache. The default implementation keeps
+// everything in the process (backed by ccache), but it can be swapped - via
+// NewCacheManagerWithBackend or NewCacheManagerFromConfig - for one that shares state
+// across instances of a clustered deployment (see NewRedisBackendFactory).
+type CacheBackend interface {
+	Get(ctx context.Context, key string) ([]byte, bool)
+	Set(ctx context.Context, key string, value []byte)
+	SetWithTTL(ctx context.Context, key string, value []byte, ttl time.Duration)
+	Delete(ctx context.Context, key string) bool
+	IsEnabled() bool
+}
+
+// BackendFactory constructs the CacheBackend for a single named cache. CacheManager calls
+// it once per distinct name, so implementations are free to size/namespace themselves
+// using the supplied arguments (e.g. a Redis key prefix, or a ccache max-size).
+type BackendFactory func(ctx context.Context, name string, size int64, ttl time.Duration) (CacheBackend, error)
+
+// CacheManager hands out named Cache instances, enforcing a single global enablement
+// switch on top of each cache's own local enablement flag.
+type CacheManager interface {
+	GetCache(ctx context.Context, name string, size int64, ttl time.Duration, enabled bool) (Cache, error)
+	ListCacheNames() []string
+}
+
+type cacheManager struct {
+	mux            sync.Mutex
+	caches         map[string]Cache
+	globalEnabled  bool
+	backendFactory BackendFactory
+}
+
+// NewCacheManager returns a CacheManager backed by the default in-process cache.
+func NewCacheManager(ctx context.Context, globalEnabled bool) CacheManager {
+	return NewCacheManagerWithBackend(ctx, globalEnabled, newInMemoryBackend)
+}
+
+// NewCacheManagerWithBackend returns a CacheManager whose caches are all constructed via
+// backendFactory, so tests (or a clustered deployment) can substitute the storage layer.
+func NewCacheManagerWithBackend(ctx context.Context, globalEnabled bool, backendFactory BackendFactory) CacheManager {
+	return &cacheManager{
+		caches:         make(map[string]Cache),
+		globalEnabled:  globalEnabled,
+		backendFactory: backendFactory,
+	}
+}
+
+func (cm *cacheManager) GetCache(ctx context.Context, name string, size int64, ttl time.Duration, enabled bool) (Cache, error) {
+	cm.mux.Lock()
+	defer cm.mux.Unlock()
+
+	if c, ok := cm.caches[name]; ok {
+		return c, nil
+	}
+
+	backend, err := cm.backendFactory(ctx, name, size, ttl)
+	if err != nil {
+		return nil, err
+	}
+	c := &managedCache{
+		backend:       backend,
+		globalEnabled: cm.globalEnabled,
+		localEnabled:  enabled,
+	}
+	cm.caches[name] = c
+	return c, nil
+}
+
+func (cm *cacheManager) ListCacheNames() []string {
+	cm.mux.Lock()
+	defer cm.mux.Unlock()
+	names := make([]string, 0, len(cm.caches))
+	for name := range cm.caches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewUmanagedCache returns a standalone in-process Cache that is not tracked by any
+// CacheManager, for callers (typically tests) that just need a scratch cache.
+func NewUmanagedCache(ctx context.Context, size int64, ttl time.Duration) Cache {
+	backend, _ := newInMemoryBackend(ctx, "unmanaged", size, ttl)
+	return &managedCache{backend: backend, globalEnabled: true, localEnabled: true}
+}
+
+// managedCache adapts a CacheBackend (which only knows about []byte) to the typed Cache
+// interface, by JSON-encoding values with a small type tag so they round-trip correctly.
+type managedCache struct {
+	backend       CacheBackend
+	globalEnabled bool
+	localEnabled  bool
+}
+
+func (c *managedCache) IsEnabled() bool {
+	return c.globalEnabled && c.localEnabled && c.backend.IsEnabled()
+}
+
+func (c *managedCache) Get(key string) interface{} {
+	if !c.IsEnabled() {
+		return nil
+	}
+	b, ok := c.backend.Get(context.Background(), key)
+	if !ok {
+		return nil
+	}
+	v, err := decodeValue(b)
+	if err != nil {
+		return nil
+	}
+	return v
+}
+
+func (c *managedCache) Set(key string, value interface{}) {
+	if !c.IsEnabled() {
+		return
+	}
+	b, err := encodeValue(value)
+	if err != nil {
+		return
+	}
+	c.backend.Set(context.Background(), key, b)
+}
+
+func (c *managedCache) GetString(key string) string {
+	v, _ := c.Get(key).(string)
+	return v
+}
+
+func (c *managedCache) SetString(key string, value string) {
+	c.Set(key, value)
+}
+
+func (c *managedCache) GetInt(key string) int {
+	v, _ := c.Get(key).(int)
+	return v
+}
+
+func (c *managedCache) SetInt(key string, value int) {
+	c.Set(key, value)
+}
+
+func (c *managedCache) GetInt64(key string) int64 {
+	v, _ := c.Get(key).(int64)
+	return v
+}
+
+func (c *managedCache) SetInt64(key string, value int64) {
+	c.Set(key, value)
+}
+
+func (c *managedCache) Delete(key string) bool {
+	if !c.IsEnabled() {
+		return false
+	}
+	return c.backend.Delete(context.Background(), key)
+}
+
+// encodedValue is the small type tag wrapper written to the backend, so a typed helper
+// (GetInt64, GetString, ...) gets back the same Go type it was given to Set.
+type encodedValue struct {
+	Kind  string          `json:"kind"`
+	Value json.RawMessage `json:"value"`
+}
+
+func encodeValue(v interface{}) ([]byte, error) {
+	var kind string
+	switch v.(type) {
+	case int:
+		kind = "int"
+	case int64:
+		kind = "int64"
+	case string:
+		kind = "string"
+	default:
+		kind = "json"
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(encodedValue{Kind: kind, Value: raw})
+}
+
+func decodeValue(b []byte) (interface{}, error) {
+	var ev encodedValue
+	if err := json.Unmarshal(b, &ev); err != nil {
+		return nil, err
+	}
+	switch ev.Kind {
+	case "int":
+		var i int
+		err := json.Unmarshal(ev.Value, &i)
+		return i, err
+	case "int64":
+		var i int64
+		err := json.Unmarshal(ev.Value, &i)
+		return i, err
+	case "string":
+		var s string
+		err := json.Unmarshal(ev.Value, &s)
+		return s, err
+	default:
+		var generic interface{}
+		err := json.Unmarshal(ev.Value, &generic)
+		return generic, err
+	}
+}
+
+// inMemoryBackend is the default CacheBackend, storing entries in an in-process ccache.
+type inMemoryBackend struct {
+	c   *ccache.Cache[[]byte]
+	ttl time.Duration
+}
+
+func newInMemoryBackend(ctx context.Context, name string, size int64, ttl time.Duration) (CacheBackend, error) {
+	return &inMemoryBackend{
+		c:   ccache.New(ccache.Configure[[]byte]().MaxSize(size)),
+		ttl: ttl,
+	}, nil
+}
+
+func (b *inMemoryBackend) Get(ctx context.Context, key string) ([]byte, bool) {
+	item := b.c.Get(key)
+	if item == nil || item.Expired() {
+		return nil, false
+	}
+	return item.Value(), true
+}
+
+func (b *inMemoryBackend) Set(ctx context.Context, key string, value []byte) {
+	b.c.Set(key, value, b.ttl)
+}
+
+func (b *inMemoryBackend) SetWithTTL(ctx context.Context, key string, value []byte, ttl time.Duration) {
+	b.c.Set(key, value, ttl)
+}
+
+func (b *inMemoryBackend) Delete(ctx context.Context, key string) bool {
+	return b.c.Delete(key)
+}
+
+func (b *inMemoryBackend) IsEnabled() bool {
+	return true
+}
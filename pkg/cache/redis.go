@@ -0,0 +1,145 @@
+// Copyright © 2023 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/firefly-common/pkg/config"
+	"github.com/hyperledger/firefly-common/pkg/i18n"
+	"github.com/hyperledger/firefly-common/pkg/log"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// ConfigCacheBackend selects the CacheBackend used by NewCacheManagerFromConfig ("memory" or "redis")
+	ConfigCacheBackend = "cache.backend"
+	// ConfigCacheRedisURL is the redis:// or rediss:// connection URL for the redis backend
+	ConfigCacheRedisURL = "cache.redis.url"
+	// ConfigCacheRedisPrefix namespaces the keys written by the redis backend, so multiple
+	// FireFly components (or deployments) can safely share one Redis instance/database
+	ConfigCacheRedisPrefix = "cache.redis.prefix"
+	// ConfigCacheRedisTLSEnabled enables TLS on the redis connection, on top of whatever the URL scheme implies
+	ConfigCacheRedisTLSEnabled = "cache.redis.tls.enabled"
+	// ConfigCacheRedisTLSInsecureSkipVerify disables server certificate verification for the redis connection
+	ConfigCacheRedisTLSInsecureSkipVerify = "cache.redis.tls.insecureSkipVerify"
+
+	cacheBackendMemory = "memory"
+	cacheBackendRedis  = "redis"
+)
+
+// InitConfig registers the cache backend selection keys understood by NewCacheManagerFromConfig
+func InitConfig(conf config.Section) {
+	conf.AddKnownKey(ConfigCacheBackend, cacheBackendMemory)
+	conf.AddKnownKey(ConfigCacheRedisURL)
+	conf.AddKnownKey(ConfigCacheRedisPrefix, "ff")
+	conf.AddKnownKey(ConfigCacheRedisTLSEnabled, false)
+	conf.AddKnownKey(ConfigCacheRedisTLSInsecureSkipVerify, false)
+}
+
+// NewCacheManagerFromConfig returns a CacheManager using the backend selected by
+// cache.backend - the default in-process cache, or a Redis-backed cache shared across
+// clustered instances (rate-limit counters, auth token caches, resolver caches, ...).
+func NewCacheManagerFromConfig(ctx context.Context, conf config.Section, globalEnabled bool) (CacheManager, error) {
+	switch conf.GetString(ConfigCacheBackend) {
+	case cacheBackendRedis:
+		factory, err := NewRedisBackendFactory(conf)
+		if err != nil {
+			return nil, err
+		}
+		return NewCacheManagerWithBackend(ctx, globalEnabled, factory), nil
+	case cacheBackendMemory, "":
+		return NewCacheManager(ctx, globalEnabled), nil
+	default:
+		return nil, i18n.NewError(ctx, i18n.MsgInvalidCacheBackend, conf.GetString(ConfigCacheBackend))
+	}
+}
+
+// NewRedisBackendFactory builds a BackendFactory that stores every named cache's entries
+// in the same Redis instance, namespaced by cache.redis.prefix and the cache name, so
+// clustered FireFly instances see a coherent view of the same logical cache.
+func NewRedisBackendFactory(conf config.Section) (BackendFactory, error) {
+	opts, err := redis.ParseURL(conf.GetString(ConfigCacheRedisURL))
+	if err != nil {
+		return nil, i18n.NewError(context.Background(), i18n.MsgInvalidRedisURL, err)
+	}
+	if conf.GetBool(ConfigCacheRedisTLSEnabled) {
+		opts.TLSConfig = &tls.Config{
+			MinVersion:         tls.VersionTLS12,
+			InsecureSkipVerify: conf.GetBool(ConfigCacheRedisTLSInsecureSkipVerify), //nolint:gosec
+		}
+	}
+	client := redis.NewClient(opts)
+	prefix := conf.GetString(ConfigCacheRedisPrefix)
+
+	return func(ctx context.Context, name string, size int64, ttl time.Duration) (CacheBackend, error) {
+		return &redisBackend{
+			client: client,
+			prefix: fmt.Sprintf("%s:%s:", prefix, name),
+			ttl:    ttl,
+		}, nil
+	}, nil
+}
+
+// redisBackend is a CacheBackend that shares its entries with every other process pointed
+// at the same Redis instance and prefix, giving clustered FireFly instances cache coherence.
+type redisBackend struct {
+	client *redis.Client
+	prefix string
+	ttl    time.Duration
+}
+
+func (b *redisBackend) key(k string) string {
+	return b.prefix + k
+}
+
+func (b *redisBackend) Get(ctx context.Context, key string) ([]byte, bool) {
+	val, err := b.client.Get(ctx, b.key(key)).Bytes()
+	if err != nil {
+		return nil, false
+	}
+	return val, true
+}
+
+func (b *redisBackend) Set(ctx context.Context, key string, value []byte) {
+	if err := b.client.Set(ctx, b.key(key), value, b.ttl).Err(); err != nil {
+		log.L(ctx).Errorf("Failed to write cache entry '%s' to redis: %s", key, err)
+	}
+}
+
+func (b *redisBackend) SetWithTTL(ctx context.Context, key string, value []byte, ttl time.Duration) {
+	if err := b.client.Set(ctx, b.key(key), value, ttl).Err(); err != nil {
+		log.L(ctx).Errorf("Failed to write cache entry '%s' to redis: %s", key, err)
+	}
+}
+
+func (b *redisBackend) Delete(ctx context.Context, key string) bool {
+	n, err := b.client.Del(ctx, b.key(key)).Result()
+	return err == nil && n > 0
+}
+
+// IsEnabled is intentionally optimistic rather than issuing a speculative PING: a
+// reachability check on every single cache operation would double the round-trips (and
+// latency) of every Get/Set/Delete against a use case - rate-limit counters, auth token
+// caches - that specifically needs to be fast. A partitioned or slow Redis instead shows
+// up per call: Get reports a miss, Delete reports false, and Set logs the error.
+func (b *redisBackend) IsEnabled() bool {
+	return true
+}
@@ -0,0 +1,104 @@
+// Copyright © 2023 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/hyperledger/firefly-common/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRedisManager(t *testing.T, globalEnabled bool) CacheManager {
+	mr, err := miniredis.Run()
+	assert.NoError(t, err)
+	t.Cleanup(mr.Close)
+
+	ctx := context.Background()
+	cp := config.RootSection("ut-cache-redis")
+	InitConfig(cp)
+	cp.Set(ConfigCacheBackend, cacheBackendRedis)
+	cp.Set(ConfigCacheRedisURL, "redis://"+mr.Addr())
+
+	factory, err := NewRedisBackendFactory(cp)
+	assert.NoError(t, err)
+	return NewCacheManagerWithBackend(ctx, globalEnabled, factory)
+}
+
+func TestRedisCacheManagerSuite(t *testing.T) {
+	cacheManagerSuite(t, func(globalEnabled bool) CacheManager {
+		return newTestRedisManager(t, globalEnabled)
+	})
+}
+
+func TestNewCacheManagerFromConfigRedis(t *testing.T) {
+	mr, err := miniredis.Run()
+	assert.NoError(t, err)
+	defer mr.Close()
+
+	ctx := context.Background()
+	cp := config.RootSection("ut-cache-from-config")
+	InitConfig(cp)
+	cp.Set(ConfigCacheBackend, cacheBackendRedis)
+	cp.Set(ConfigCacheRedisURL, "redis://"+mr.Addr())
+
+	cm, err := NewCacheManagerFromConfig(ctx, cp, true)
+	assert.NoError(t, err)
+
+	c, err := cm.GetCache(ctx, "cacheA", 85, time.Second, true)
+	assert.NoError(t, err)
+	c.SetString("key", "value")
+	assert.Equal(t, "value", c.GetString("key"))
+}
+
+func TestNewCacheManagerFromConfigRedisTLS(t *testing.T) {
+	ctx := context.Background()
+	cp := config.RootSection("ut-cache-from-config-tls")
+	InitConfig(cp)
+	cp.Set(ConfigCacheBackend, cacheBackendRedis)
+	cp.Set(ConfigCacheRedisURL, "rediss://127.0.0.1:6379")
+	cp.Set(ConfigCacheRedisTLSEnabled, true)
+	cp.Set(ConfigCacheRedisTLSInsecureSkipVerify, true)
+
+	cm, err := NewCacheManagerFromConfig(ctx, cp, true)
+	assert.NoError(t, err)
+	assert.NotNil(t, cm)
+}
+
+func TestNewCacheManagerFromConfigInvalidBackend(t *testing.T) {
+	ctx := context.Background()
+	cp := config.RootSection("ut-cache-from-config-invalid-backend")
+	InitConfig(cp)
+	cp.Set(ConfigCacheBackend, "banana")
+
+	_, err := NewCacheManagerFromConfig(ctx, cp, true)
+	assert.Regexp(t, "FF00160", err)
+}
+
+func TestNewCacheManagerFromConfigInvalidURL(t *testing.T) {
+	ctx := context.Background()
+	cp := config.RootSection("ut-cache-from-config-invalid-url")
+	InitConfig(cp)
+	cp.Set(ConfigCacheBackend, cacheBackendRedis)
+	cp.Set(ConfigCacheRedisURL, "redis://127.0.0.1:not-a-port")
+
+	_, err := NewCacheManagerFromConfig(ctx, cp, true)
+	assert.Regexp(t, "FF00161", err)
+}
@@ -1,4 +1,4 @@
-// Copyright © 2022 Kaleido, Inc.
+// Copyright © 2023 Kaleido, Inc.
 //
 // SPDX-License-Identifier: Apache-2.0
 //
@@ -19,22 +19,83 @@ package fftypes
 import (
 	"context"
 	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/hyperledger/firefly-common/pkg/i18n"
 )
 
 type FFEnum string
 
+var enumMux sync.Mutex
 var enumValues = map[string][]interface{}{}
+var enumValuesLower = map[string]map[string]bool{}
 
+// FFEnumValue registers val as one of the allowed values of the enum type t, and returns
+// it as an FFEnum. It panics if val (compared case-insensitively) has already been
+// registered for t - two init() functions racing to define the same enum value is always
+// a programming error, so we fail loudly rather than let the second definition silently win.
 func FFEnumValue(t string, val string) FFEnum {
-	enumValues[t] = append(enumValues[t], val)
+	enumMux.Lock()
+	defer enumMux.Unlock()
+
+	typeKey := strings.ToLower(t)
+	valKey := strings.ToLower(val)
+	if enumValuesLower[typeKey] == nil {
+		enumValuesLower[typeKey] = map[string]bool{}
+	}
+	if enumValuesLower[typeKey][valKey] {
+		panic(fmt.Sprintf("duplicate FFEnum value %q registered for type %q", val, t))
+	}
+	enumValuesLower[typeKey][valKey] = true
+	enumValues[typeKey] = append(enumValues[typeKey], val)
 	return FFEnum(val)
 }
 
 func FFEnumValues(t string) []interface{} {
-	return enumValues[t]
+	enumMux.Lock()
+	defer enumMux.Unlock()
+	return enumValues[strings.ToLower(t)]
+}
+
+// FFEnumTypes returns the names of all FFEnum types that have had at least one value
+// registered via FFEnumValue, sorted for stable output (e.g. in generated docs).
+func FFEnumTypes() []string {
+	enumMux.Lock()
+	defer enumMux.Unlock()
+	types := make([]string, 0, len(enumValues))
+	for t := range enumValues {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// FFEnumValuesTyped returns the values registered for FFEnum type t, cast to the caller's
+// own FFEnum-derived type T, so generated APIs can work with their named type directly
+// instead of the bare FFEnum string.
+func FFEnumValuesTyped[T ~string](t string) []T {
+	enumMux.Lock()
+	defer enumMux.Unlock()
+	raw := enumValues[strings.ToLower(t)]
+	out := make([]T, 0, len(raw))
+	for _, v := range raw {
+		out = append(out, T(v.(string)))
+	}
+	return out
+}
+
+// JSONSchema returns a JSON schema fragment (splice-able into an OpenAPI document) that
+// constrains a string field to the values registered for FFEnum type t.
+func JSONSchema(t string) map[string]interface{} {
+	return map[string]interface{}{
+		"type": "string",
+		"enum": FFEnumValues(t),
+	}
 }
 
 func (ts FFEnum) String() string {
@@ -58,8 +119,23 @@ func (ts *FFEnum) UnmarshalText(b []byte) error {
 	return nil
 }
 
+func (ts FFEnum) MarshalJSON() ([]byte, error) {
+	return json.Marshal(ts.String())
+}
+
+func (ts *FFEnum) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	*ts = FFEnum(strings.ToLower(s))
+	return nil
+}
+
 func FFEnumParseString(ctx context.Context, t string, i string) (FFEnum, error) {
+	enumMux.Lock()
 	e, ok := enumValues[strings.ToLower(t)]
+	enumMux.Unlock()
 	if !ok {
 		return "", i18n.NewError(ctx, i18n.MsgInvalidEnum, t)
 	}
@@ -70,3 +146,37 @@ func FFEnumParseString(ctx context.Context, t string, i string) (FFEnum, error)
 	}
 	return "", i18n.NewError(ctx, i18n.MsgInvalidEnumValue, i, t, e)
 }
+
+// ValidateStruct walks the fields of the struct pointed to by v (one level deep)
+// and, for every FFEnum field tagged `ffenum:"<typename>"`, checks its value against the
+// values registered for that type. It is intended to be called after unmarshalling external
+// input, so a mistyped enum value (e.g. surviving UnmarshalJSON because that has no type
+// context of its own) is rejected with the same FF00xxx error FFEnumParseString would give.
+func ValidateStruct(ctx context.Context, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		typeName, ok := rt.Field(i).Tag.Lookup("ffenum")
+		if !ok {
+			continue
+		}
+		fv := rv.Field(i)
+		if fv.Kind() != reflect.String || fv.String() == "" {
+			continue
+		}
+		if _, err := FFEnumParseString(ctx, typeName, fv.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
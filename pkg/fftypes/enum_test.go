@@ -0,0 +1,72 @@
+// Copyright © 2023 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testEnumType = "testenumtype"
+
+var (
+	TestEnumFoo = FFEnumValue(testEnumType, "foo")
+	TestEnumBar = FFEnumValue(testEnumType, "bar")
+)
+
+func TestFFEnumValueDuplicatePanics(t *testing.T) {
+	assert.Panics(t, func() {
+		FFEnumValue(testEnumType, "Foo")
+	})
+}
+
+func TestFFEnumTypesAndValuesTyped(t *testing.T) {
+	assert.Contains(t, FFEnumTypes(), testEnumType)
+	assert.ElementsMatch(t, []FFEnum{TestEnumFoo, TestEnumBar}, FFEnumValuesTyped[FFEnum](testEnumType))
+}
+
+func TestJSONSchema(t *testing.T) {
+	schema := JSONSchema(testEnumType)
+	assert.Equal(t, "string", schema["type"])
+	assert.ElementsMatch(t, []interface{}{"foo", "bar"}, schema["enum"])
+}
+
+type testEnumStruct struct {
+	Status FFEnum `json:"status" ffenum:"testenumtype"`
+}
+
+func TestValidateStructValidValue(t *testing.T) {
+	s := &testEnumStruct{Status: TestEnumFoo}
+	assert.NoError(t, ValidateStruct(context.Background(), s))
+}
+
+func TestValidateStructMistypedValueFromJSON(t *testing.T) {
+	var s testEnumStruct
+	err := json.Unmarshal([]byte(`{"status":"not-a-real-value"}`), &s)
+	assert.NoError(t, err)
+
+	err = ValidateStruct(context.Background(), &s)
+	assert.Regexp(t, "FF00", err)
+}
+
+func TestValidateStructIgnoresNonStructInput(t *testing.T) {
+	assert.NoError(t, ValidateStruct(context.Background(), "not-a-struct"))
+	assert.NoError(t, ValidateStruct(context.Background(), (*testEnumStruct)(nil)))
+}
@@ -0,0 +1,409 @@
+// Copyright © 2023 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpserver
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/hyperledger/firefly-common/pkg/auth"
+	"github.com/hyperledger/firefly-common/pkg/auth/authfactory"
+	"github.com/hyperledger/firefly-common/pkg/config"
+	"github.com/hyperledger/firefly-common/pkg/i18n"
+	"github.com/hyperledger/firefly-common/pkg/log"
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+const (
+	HTTPConfAddress           = "address"
+	HTTPConfPort              = "port"
+	HTTPConfPublicURL         = "publicURL"
+	HTTPConfReadTimeout       = "readTimeout"
+	HTTPConfWriteTimeout      = "writeTimeout"
+	HTTPConfShutdownTimeout   = "shutdownTimeout"
+	HTTPAuthType              = "auth.type"
+	HTTPConfTLSEnabled        = "tls.enabled"
+	HTTPConfTLSClientAuth     = "tls.clientAuth"
+	HTTPConfTLSCAFile         = "tls.caFile"
+	HTTPConfTLSCertFile       = "tls.certFile"
+	HTTPConfTLSKeyFile        = "tls.keyFile"
+	HTTPConfTLSReloadInterval = "tls.reloadInterval"
+	HTTPConfTLSACMEEnabled    = "tls.acme.enabled"
+	HTTPConfTLSACMEDirectory  = "tls.acme.directoryURL"
+	HTTPConfTLSACMEEmail      = "tls.acme.email"
+	HTTPConfTLSACMEHosts      = "tls.acme.hostnames"
+	HTTPConfTLSACMECachePath  = "tls.acme.cachePath"
+	HTTPConfTLSACMEChallenge  = "tls.acme.challenge"
+)
+
+const (
+	acmeChallengeHTTP01    = "http-01"
+	acmeChallengeTLSALPN01 = "tls-alpn-01"
+)
+
+// InitHTTPConfig initializes the config keys understood by NewHTTPServer
+func InitHTTPConfig(cp config.Section, defaultPort int) {
+	cp.AddKnownKey(HTTPConfAddress, "0.0.0.0")
+	cp.AddKnownKey(HTTPConfPort, defaultPort)
+	cp.AddKnownKey(HTTPConfPublicURL)
+	cp.AddKnownKey(HTTPConfReadTimeout, "15s")
+	cp.AddKnownKey(HTTPConfWriteTimeout, "15s")
+	cp.AddKnownKey(HTTPConfShutdownTimeout, "10s")
+	cp.AddKnownKey(HTTPAuthType)
+	cp.AddKnownKey(HTTPConfTLSEnabled, false)
+	cp.AddKnownKey(HTTPConfTLSClientAuth, false)
+	cp.AddKnownKey(HTTPConfTLSCAFile)
+	cp.AddKnownKey(HTTPConfTLSCertFile)
+	cp.AddKnownKey(HTTPConfTLSKeyFile)
+	cp.AddKnownKey(HTTPConfTLSReloadInterval, "0s")
+	cp.AddKnownKey(HTTPConfTLSACMEEnabled, false)
+	cp.AddKnownKey(HTTPConfTLSACMEDirectory, acme.LetsEncryptURL)
+	cp.AddKnownKey(HTTPConfTLSACMEEmail)
+	cp.AddKnownKey(HTTPConfTLSACMEHosts)
+	cp.AddKnownKey(HTTPConfTLSACMECachePath)
+	cp.AddKnownKey(HTTPConfTLSACMEChallenge, acmeChallengeHTTP01)
+}
+
+// InitCORSConfig initializes the CORS config keys
+func InitCORSConfig(cc config.Section) {
+	cc.AddKnownKey("enabled", true)
+	cc.AddKnownKey("origins", "*")
+	cc.AddKnownKey("methods", "GET,POST,PUT,PATCH,DELETE")
+	cc.AddKnownKey("headers", "*")
+	cc.AddKnownKey("maxAge", "600")
+}
+
+// GoHTTPServer is the subset of *http.Server that we use, so it can be mocked in tests
+type GoHTTPServer interface {
+	Serve(l net.Listener) error
+	Shutdown(ctx context.Context) error
+}
+
+// ServerOptions allows tuning of behavior that is not exposed directly via config
+type ServerOptions struct {
+	MaximumRequestTimeout time.Duration
+}
+
+// HTTPServer is the interface to a configured, but not yet listening, HTTP server
+type HTTPServer interface {
+	Addr() net.Addr
+	ServeHTTP(ctx context.Context)
+	// Reload re-reads the configured TLS certificate/key material from disk, without
+	// dropping the listener or disrupting in-flight connections.
+	Reload() error
+}
+
+type httpServer struct {
+	name            string
+	l               net.Listener
+	s               GoHTTPServer
+	options         *ServerOptions
+	errChan         chan error
+	shutdownTimeout time.Duration
+
+	certPath       string
+	keyPath        string
+	caPath         string
+	clientAuth     bool
+	reloadInterval time.Duration
+	cert           atomic.Value // *tls.Certificate
+	caPool         atomic.Value // *x509.CertPool
+}
+
+func NewHTTPServer(ctx context.Context, name string, r *mux.Router, errChan chan error, cp config.Section, cc config.Section, options ...*ServerOptions) (is HTTPServer, err error) {
+	hs := &httpServer{
+		name:            name,
+		errChan:         errChan,
+		options:         &ServerOptions{},
+		shutdownTimeout: cp.GetDuration(HTTPConfShutdownTimeout),
+	}
+	if len(options) > 0 {
+		hs.options = options[0]
+	}
+
+	addr := cp.GetString(HTTPConfAddress)
+	port := cp.GetInt(HTTPConfPort)
+	hs.l, err = net.Listen("tcp", fmt.Sprintf("%s:%d", addr, port))
+	if err != nil {
+		return nil, i18n.NewError(ctx, i18n.MsgHTTPServerStartFailed, err)
+	}
+
+	tlsConfig, err := hs.buildTLSConfig(ctx, cp, r)
+	if err != nil {
+		return nil, err
+	}
+
+	handler := http.Handler(r)
+	if authType := cp.GetString(HTTPAuthType); authType != "" {
+		plugin, err := authfactory.GetPlugin(ctx, authType, cp.SubSection("auth"))
+		if err != nil {
+			return nil, err
+		}
+		handler = withAuth(plugin, handler)
+	}
+
+	server := &http.Server{
+		Handler:      handler,
+		ReadTimeout:  cp.GetDuration(HTTPConfReadTimeout),
+		WriteTimeout: cp.GetDuration(HTTPConfWriteTimeout),
+		TLSConfig:    tlsConfig,
+	}
+	hs.s = server
+
+	if tlsConfig != nil {
+		hs.l = tls.NewListener(hs.l, tlsConfig)
+	}
+
+	return hs, nil
+}
+
+// buildTLSConfig constructs the tls.Config for the server, wiring up ACME if configured
+// or falling back to the static tls.enabled/certFile/keyFile/caFile configuration. In the
+// static case the certificate and CA pool are read once into an atomic cache, and served
+// via GetCertificate/GetConfigForClient so they can be hot-reloaded without a restart.
+func (hs *httpServer) buildTLSConfig(ctx context.Context, cp config.Section, r *mux.Router) (*tls.Config, error) {
+	if cp.GetBool(HTTPConfTLSACMEEnabled) {
+		return hs.buildACMETLSConfig(ctx, cp, r)
+	}
+
+	if !cp.GetBool(HTTPConfTLSEnabled) && cp.GetString(HTTPConfTLSCAFile) == "" {
+		return nil, nil
+	}
+
+	hs.certPath = cp.GetString(HTTPConfTLSCertFile)
+	hs.keyPath = cp.GetString(HTTPConfTLSKeyFile)
+	hs.caPath = cp.GetString(HTTPConfTLSCAFile)
+	hs.clientAuth = cp.GetBool(HTTPConfTLSClientAuth)
+	hs.reloadInterval = cp.GetDuration(HTTPConfTLSReloadInterval)
+
+	if err := hs.reloadTLSMaterial(ctx); err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cp.GetBool(HTTPConfTLSEnabled) {
+		tlsConfig.GetCertificate = hs.getCertificate
+	}
+	if hs.caPath != "" {
+		tlsConfig.GetConfigForClient = hs.getConfigForClient
+	}
+
+	return tlsConfig, nil
+}
+
+// reloadTLSMaterial re-reads the configured key/cert (and CA, if configured) from disk and
+// atomically swaps them in. On failure the previously loaded material is left untouched, so
+// a bad renewal never takes a running listener down.
+func (hs *httpServer) reloadTLSMaterial(ctx context.Context) error {
+	var cert *tls.Certificate
+	if hs.certPath != "" || hs.keyPath != "" {
+		loaded, err := tls.LoadX509KeyPair(hs.certPath, hs.keyPath)
+		if err != nil {
+			return i18n.NewError(ctx, i18n.MsgInvalidKeyPairFiles, err)
+		}
+		cert = &loaded
+	}
+
+	var rootCAs *x509.CertPool
+	if hs.caPath != "" {
+		caBytes, err := os.ReadFile(hs.caPath)
+		if err != nil {
+			return i18n.NewError(ctx, i18n.MsgMissingCAFile, err)
+		}
+		rootCAs = x509.NewCertPool()
+		if !rootCAs.AppendCertsFromPEM(caBytes) {
+			return i18n.NewError(ctx, i18n.MsgInvalidCAFile, hs.caPath)
+		}
+	}
+
+	// Only swap once everything has parsed, so a failure leaves the previous material in place
+	if cert != nil {
+		hs.cert.Store(cert)
+	}
+	if rootCAs != nil {
+		hs.caPool.Store(rootCAs)
+	}
+	return nil
+}
+
+func (hs *httpServer) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	cert, _ := hs.cert.Load().(*tls.Certificate)
+	if cert == nil {
+		return nil, i18n.NewError(context.Background(), i18n.MsgInvalidKeyPairFiles, "no certificate loaded")
+	}
+	return cert, nil
+}
+
+// getConfigForClient is invoked per-handshake so that a renewed CA bundle is honored without
+// restarting the listener - ClientCAs itself can't be swapped on a live *tls.Config.
+func (hs *httpServer) getConfigForClient(*tls.ClientHelloInfo) (*tls.Config, error) {
+	cfg := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: hs.getCertificate,
+	}
+	if pool, _ := hs.caPool.Load().(*x509.CertPool); pool != nil {
+		cfg.ClientCAs = pool
+		if hs.clientAuth {
+			cfg.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+	return cfg, nil
+}
+
+// Reload re-reads the configured TLS key/cert/CA material from disk. It is safe to call
+// concurrently with in-flight handshakes, and is exposed so admin endpoints or tests can
+// force a reload rather than waiting on the tls.reloadInterval poll.
+func (hs *httpServer) Reload() error {
+	return hs.reloadTLSMaterial(context.Background())
+}
+
+// watchTLSReload polls the configured key/cert/CA files for mtime changes on tls.reloadInterval,
+// re-reading them in the background for the lifetime of ctx. Long-running services that rotate
+// certificates out-of-band (cert-manager, step-ca renew, a projected Kubernetes secret) pick up
+// the new material without a restart.
+func (hs *httpServer) watchTLSReload(ctx context.Context) {
+	if hs.reloadInterval <= 0 || (hs.certPath == "" && hs.keyPath == "" && hs.caPath == "") {
+		return
+	}
+	ticker := time.NewTicker(hs.reloadInterval)
+	defer ticker.Stop()
+	lastMod := hs.tlsMaterialModTime()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			modTime := hs.tlsMaterialModTime()
+			if !modTime.After(lastMod) {
+				continue
+			}
+			if err := hs.reloadTLSMaterial(ctx); err != nil {
+				log.L(ctx).Errorf("Failed to reload TLS material: %s", err)
+				continue
+			}
+			lastMod = modTime
+		}
+	}
+}
+
+func (hs *httpServer) tlsMaterialModTime() time.Time {
+	var latest time.Time
+	for _, path := range []string{hs.certPath, hs.keyPath, hs.caPath} {
+		if path == "" {
+			continue
+		}
+		if info, err := os.Stat(path); err == nil && info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest
+}
+
+// acmeHTTP01ChallengePath is the well-known path ACME dictates for http-01 validation
+// requests; autocert.Manager.HTTPHandler answers anything under it itself.
+const acmeHTTP01ChallengePath = "/.well-known/acme-challenge/"
+
+// buildACMETLSConfig wires up an autocert.Manager to automatically obtain and renew
+// certificates from an ACME v2 directory (such as Let's Encrypt), rather than relying
+// on statically configured key/cert files.
+func (hs *httpServer) buildACMETLSConfig(ctx context.Context, cp config.Section, r *mux.Router) (*tls.Config, error) {
+	hostnames := cp.GetStringSlice(HTTPConfTLSACMEHosts)
+	if len(hostnames) == 0 {
+		return nil, i18n.NewError(ctx, i18n.MsgACMEConfigInvalid, HTTPConfTLSACMEHosts)
+	}
+
+	m := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(hostnames...),
+		Email:      cp.GetString(HTTPConfTLSACMEEmail),
+		Client: &acme.Client{
+			DirectoryURL: cp.GetString(HTTPConfTLSACMEDirectory),
+		},
+	}
+	if cachePath := cp.GetString(HTTPConfTLSACMECachePath); cachePath != "" {
+		m.Cache = autocert.DirCache(cachePath)
+	}
+
+	challenge := strings.ToLower(cp.GetString(HTTPConfTLSACMEChallenge))
+	switch challenge {
+	case acmeChallengeTLSALPN01:
+		// nothing further required - autocert negotiates tls-alpn-01 itself, as part of the
+		// TLS handshake, via the GetCertificate set up by m.TLSConfig() below
+	case acmeChallengeHTTP01, "":
+		// The http-01 responder is mounted directly on the router rather than on this
+		// listener's own handler chain, because this listener is about to be TLS-wrapped
+		// (see NewHTTPServer) and can never see the plaintext validation request ACME sends
+		// for http-01. Mounting on the router instead means it is answered by whichever
+		// listener actually serves that path - in practice a companion plaintext
+		// NewHTTPServer (e.g. on port 80) pointed at the same router.
+		r.PathPrefix(acmeHTTP01ChallengePath).Handler(m.HTTPHandler(nil))
+	default:
+		return nil, i18n.NewError(ctx, i18n.MsgACMEConfigInvalid, HTTPConfTLSACMEChallenge)
+	}
+
+	return m.TLSConfig(), nil
+}
+
+func (hs *httpServer) Addr() net.Addr {
+	return hs.l.Addr()
+}
+
+func (hs *httpServer) ServeHTTP(ctx context.Context) {
+	go hs.watchTLSReload(ctx)
+
+	serverEnded := make(chan error)
+	go func() {
+		serverEnded <- hs.s.Serve(hs.l)
+	}()
+
+	select {
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), hs.shutdownTimeout)
+		defer cancel()
+		if err := hs.s.Shutdown(shutdownCtx); err != nil {
+			hs.errChan <- err
+			return
+		}
+		hs.errChan <- <-serverEnded
+	case err := <-serverEnded:
+		hs.errChan <- err
+	}
+}
+
+func withAuth(plugin auth.Plugin, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		authedCtx, err := plugin.Authenticate(req.Context(), req)
+		if err != nil {
+			res.Header().Set("Content-Type", "application/json")
+			res.WriteHeader(http.StatusForbidden)
+			_ = json.NewEncoder(res).Encode(map[string]interface{}{"error": err.Error()})
+			return
+		}
+		next.ServeHTTP(res, req.WithContext(authedCtx))
+	})
+}
@@ -31,6 +31,7 @@ import (
 	"math/big"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 	"time"
@@ -41,6 +42,7 @@ import (
 	"github.com/hyperledger/firefly-common/pkg/config"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/acme/autocert/acmetest"
 )
 
 const configDir = "../../test/data/config"
@@ -219,6 +221,80 @@ func TestTLSServerSelfSignedWithClientAuth(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func writeSelfSignedCert(t *testing.T, keyFile, certFile string, serial int64) []byte {
+	privatekey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	publickey := &privatekey.PublicKey
+	privateKeyBytes := x509.MarshalPKCS1PrivateKey(privatekey)
+	kf, err := os.OpenFile(keyFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	assert.NoError(t, err)
+	defer kf.Close()
+	pem.Encode(kf, &pem.Block{Type: "RSA PRIVATE KEY", Bytes: privateKeyBytes})
+
+	x509Template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{Organization: []string{"Unit Tests"}},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(100 * time.Second),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+	derBytes, err := x509.CreateCertificate(rand.Reader, x509Template, x509Template, publickey, privatekey)
+	assert.NoError(t, err)
+	cf, err := os.OpenFile(certFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	assert.NoError(t, err)
+	defer cf.Close()
+	pem.Encode(cf, &pem.Block{Type: "CERTIFICATE", Bytes: derBytes})
+	return derBytes
+}
+
+func TestTLSHotReloadOnDemand(t *testing.T) {
+	config.RootConfigReset()
+
+	keyFile, err := ioutil.TempFile("", "key.pem")
+	assert.NoError(t, err)
+	defer os.Remove(keyFile.Name())
+	certFile, err := ioutil.TempFile("", "cert.pem")
+	assert.NoError(t, err)
+	defer os.Remove(certFile.Name())
+
+	firstDER := writeSelfSignedCert(t, keyFile.Name(), certFile.Name(), 1)
+
+	cp := config.RootSection("ut")
+	InitHTTPConfig(cp, 0)
+	cc := config.RootSection("utCors")
+	InitCORSConfig(cc)
+	cp.Set(HTTPConfAddress, "127.0.0.1")
+	cp.Set(HTTPConfTLSEnabled, true)
+	cp.Set(HTTPConfTLSKeyFile, keyFile.Name())
+	cp.Set(HTTPConfTLSCertFile, certFile.Name())
+	cp.Set(HTTPConfPort, 0)
+
+	r := mux.NewRouter()
+	errChan := make(chan error)
+	hs, err := NewHTTPServer(context.Background(), "ut", r, errChan, cp, cc)
+	assert.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	go hs.ServeHTTP(ctx)
+
+	dial := func() []byte {
+		c, err := tls.Dial("tcp", hs.(*httpServer).l.Addr().String(), &tls.Config{InsecureSkipVerify: true})
+		assert.NoError(t, err)
+		defer c.Close()
+		return c.ConnectionState().PeerCertificates[0].Raw
+	}
+
+	assert.Equal(t, firstDER, dial())
+
+	secondDER := writeSelfSignedCert(t, keyFile.Name(), certFile.Name(), 2)
+	assert.NoError(t, hs.Reload())
+	assert.Equal(t, secondDER, dial())
+	assert.NotEqual(t, firstDER, secondDER)
+
+	cancel()
+	<-errChan
+}
+
 func TestServeAuthorization(t *testing.T) {
 	config.RootConfigReset()
 	cp := config.RootSection("ut")
@@ -267,6 +343,100 @@ func TestServeAuthorization(t *testing.T) {
 	}
 }
 
+func TestACMERequiresHostnames(t *testing.T) {
+	config.RootConfigReset()
+	cp := config.RootSection("ut")
+	InitHTTPConfig(cp, 0)
+	cc := config.RootSection("utCors")
+	InitCORSConfig(cc)
+	cp.Set(HTTPConfTLSACMEEnabled, true)
+	_, err := NewHTTPServer(context.Background(), "ut", mux.NewRouter(), make(chan error), cp, cc)
+	assert.Regexp(t, "FF00", err)
+}
+
+func TestACMEHTTP01ChallengeMountedOnRouter(t *testing.T) {
+	config.RootConfigReset()
+	cp := config.RootSection("ut")
+	InitHTTPConfig(cp, 0)
+	cc := config.RootSection("utCors")
+	InitCORSConfig(cc)
+	cp.Set(HTTPConfTLSACMEEnabled, true)
+	cp.Set(HTTPConfTLSACMEHosts, []string{"example.test"})
+	cp.Set(HTTPConfTLSACMECachePath, "")
+	cp.Set(HTTPConfTLSACMEChallenge, "http-01")
+
+	r := mux.NewRouter()
+	r.HandleFunc("/test", func(res http.ResponseWriter, req *http.Request) {
+		res.WriteHeader(200)
+	})
+	_, err := NewHTTPServer(context.Background(), "ut", r, make(chan error), cp, cc)
+	assert.NoError(t, err)
+
+	// The challenge responder is mounted on the router itself - not this listener's (TLS-wrapped)
+	// handler chain - so a companion plaintext listener pointed at the same router can answer it.
+	// An unknown token 404s rather than falling through to the app's own /test handler, proving
+	// the route is actually registered.
+	req := httptest.NewRequest("GET", "/.well-known/acme-challenge/unknown-token", nil)
+	req.Host = "example.test" // a host outside tls.acme.hostnames is refused by the host policy (403) before the token lookup
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusNotFound, res.Code)
+}
+
+func TestACMEServesCertFromStubDirectory(t *testing.T) {
+	config.RootConfigReset()
+
+	// This server only ever exposes a single, TLS-wrapped listener - there is no plaintext
+	// port for the stub CA to complete an http-01 authorization against, so tls-alpn-01 (which
+	// is answered entirely within the TLS handshake by autocert's GetCertificate) is what the
+	// test - and a real deployment of this server - actually exercises end to end.
+	ca := acmetest.NewCAServer(acmetest.ChallengeTypeTLSALPN01)
+	defer ca.Close()
+
+	cp := config.RootSection("ut")
+	InitHTTPConfig(cp, 0)
+	cc := config.RootSection("utCors")
+	InitCORSConfig(cc)
+	cp.Set(HTTPConfAddress, "127.0.0.1")
+	cp.Set(HTTPConfTLSACMEEnabled, true)
+	cp.Set(HTTPConfTLSACMEDirectory, ca.URL)
+	cp.Set(HTTPConfTLSACMEHosts, []string{"example.test"})
+	cp.Set(HTTPConfTLSACMECachePath, "")
+	cp.Set(HTTPConfTLSACMEChallenge, "tls-alpn-01")
+
+	r := mux.NewRouter()
+	r.HandleFunc("/test", func(res http.ResponseWriter, req *http.Request) {
+		res.WriteHeader(200)
+	})
+	errChan := make(chan error)
+	hs, err := NewHTTPServer(context.Background(), "ut", r, errChan, cp, cc)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go hs.ServeHTTP(ctx)
+
+	// Point the stub CA at our listener, so it can dial back in to validate the challenge
+	ca.Resolve("example.test", hs.(*httpServer).l.Addr().String())
+
+	c := http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{
+				ServerName:         "example.test",
+				InsecureSkipVerify: true,
+			},
+		},
+	}
+	httpsAddr := fmt.Sprintf("https://%s/test", hs.(*httpServer).l.Addr().String())
+	res, err := c.Get(httpsAddr)
+	assert.NoError(t, err)
+	if res != nil {
+		assert.Equal(t, 200, res.StatusCode)
+	}
+
+	cancel()
+	<-errChan
+}
+
 func TestServeAuthorizationBadPluginName(t *testing.T) {
 	config.RootConfigReset()
 	cp := config.RootSection("ut")
@@ -0,0 +1,75 @@
+// Copyright © 2023 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package i18n is a minimal message catalog: every error surfaced to an operator or API
+// caller is built from a MessageKey (a stable "FF00nnn" code) plus a template, so the same
+// failure always renders with the same code regardless of which package raised it.
+package i18n
+
+import (
+	"context"
+	"fmt"
+)
+
+// MessageKey is a stable "FF00nnn" error/message code
+type MessageKey string
+
+var messages = map[MessageKey]string{}
+
+// ffe registers key/template pairs in the catalog as package-level vars are initialized,
+// so every MessageKey used anywhere in the module is declared exactly once, here.
+func ffe(key MessageKey, template string) MessageKey {
+	if _, exists := messages[key]; exists {
+		panic(fmt.Sprintf("duplicate i18n message key %q", key))
+	}
+	messages[key] = template
+	return key
+}
+
+var (
+	MsgInvalidEnum           = ffe("FF00110", "Unknown enum type '%s'")
+	MsgInvalidEnumValue      = ffe("FF00111", "Value '%s' is not a valid '%s'. Valid options: %v")
+	MsgInvalidCAFile         = ffe("FF00152", "Invalid CA certificates file '%s'")
+	MsgMissingCAFile         = ffe("FF00153", "Failed to read CA certificates file: %s")
+	MsgInvalidKeyPairFiles   = ffe("FF00154", "Failed to load TLS key/certificate pair: %s")
+	MsgHTTPServerStartFailed = ffe("FF00155", "Failed to listen: %s")
+	MsgACMEConfigInvalid     = ffe("FF00156", "Invalid ACME configuration value for '%s'")
+	MsgInvalidCacheBackend   = ffe("FF00160", "Invalid cache backend '%s'")
+	MsgInvalidRedisURL       = ffe("FF00161", "Invalid Redis URL: %s")
+)
+
+type ffError struct {
+	key     MessageKey
+	message string
+}
+
+func (e *ffError) Error() string {
+	return e.message
+}
+
+// NewError renders key's registered template with inserts, and prefixes the result with
+// the message key itself (e.g. "FF00152: Invalid CA certificates file '...'"), so the code
+// is always present in the error string for callers/tests that match on it.
+func NewError(ctx context.Context, key MessageKey, inserts ...interface{}) error {
+	template, ok := messages[key]
+	if !ok {
+		template = "unregistered message"
+	}
+	return &ffError{
+		key:     key,
+		message: fmt.Sprintf(string(key)+": "+template, inserts...),
+	}
+}